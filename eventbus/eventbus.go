@@ -0,0 +1,79 @@
+// Package eventbus is a small publish-subscribe hub that decouples the
+// routing simulators (lab11's distance-vector Node, lab12's RIP Router) from
+// how their output is consumed. Simulators call Publish with one of the
+// event types below; anything interested calls Subscribe and drains Ch.
+package eventbus
+
+import "sync"
+
+// Subscriber receives every event published under the eventType it was
+// created with. Call Unsubscribe when done to stop receiving events and let
+// the bus release it.
+type Subscriber struct {
+	Ch   chan interface{}
+	Quit chan struct{}
+
+	eventType string
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = make(map[string][]*Subscriber)
+)
+
+// Subscribe returns a Subscriber that receives every event published under
+// eventType. Pass "" to subscribe to every event type.
+func Subscribe(eventType string) *Subscriber {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sub := &Subscriber{
+		Ch:        make(chan interface{}, 32),
+		Quit:      make(chan struct{}),
+		eventType: eventType,
+	}
+	subscribers[eventType] = append(subscribers[eventType], sub)
+	return sub
+}
+
+// Unsubscribe closes sub.Quit and removes sub from the bus. Safe to call
+// more than once.
+func Unsubscribe(sub *Subscriber) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	select {
+	case <-sub.Quit:
+		return
+	default:
+		close(sub.Quit)
+	}
+
+	subs := subscribers[sub.eventType]
+	for i, s := range subs {
+		if s == sub {
+			subscribers[sub.eventType] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// Publish delivers event to every subscriber of eventType plus every
+// subscriber of the wildcard "" type. Delivery is non-blocking: a subscriber
+// whose channel is full drops the event rather than stalling the publisher.
+func Publish(eventType string, event interface{}) {
+	mu.Lock()
+	recipients := make([]*Subscriber, 0, len(subscribers[eventType])+len(subscribers[""]))
+	recipients = append(recipients, subscribers[eventType]...)
+	if eventType != "" {
+		recipients = append(recipients, subscribers[""]...)
+	}
+	mu.Unlock()
+
+	for _, sub := range recipients {
+		select {
+		case sub.Ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package eventbus
+
+// Event type names used with Subscribe/Publish. RouterID/NodeID are carried
+// as strings on every event so that both lab12's IP-addressed Router and
+// lab11's integer-ID Node can publish through the same bus.
+const (
+	RouteInstalledType     = "RouteInstalled"
+	RouteWithdrawnType     = "RouteWithdrawn"
+	MetricChangedType      = "MetricChanged"
+	NeighborLostType       = "NeighborLost"
+	ConvergenceReachedType = "ConvergenceReached"
+)
+
+// RouteInstalled is published the first time a router/node learns a route
+// to Destination.
+type RouteInstalled struct {
+	RouterID    string
+	Destination string
+	NextHop     string
+	Metric      int
+}
+
+// RouteWithdrawn is published when a destination that was reachable becomes
+// unreachable (metric raised to MAX_METRIC/INFINITY).
+type RouteWithdrawn struct {
+	RouterID    string
+	Destination string
+}
+
+// MetricChanged is published when an already-installed route's metric
+// changes without becoming unreachable.
+type MetricChanged struct {
+	RouterID    string
+	Destination string
+	OldMetric   int
+	NewMetric   int
+}
+
+// NeighborLost is published when a direct link to a neighbor goes down.
+type NeighborLost struct {
+	RouterID   string
+	NeighborID string
+}
+
+// ConvergenceReached is published by a simulation driver once no router's
+// table changed for a full round (or the iteration budget ran out).
+type ConvergenceReached struct {
+	Iteration int
+}
@@ -0,0 +1,107 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// RunConsolePrinter prints one line per event received on sub until sub.Quit
+// is closed, then prints whatever is left buffered on sub.Ch before
+// returning. This is the default subscriber used in place of the
+// simulators' old direct fmt.Printf table dumps; a caller that wants every
+// event printed (e.g. the final ConvergenceReached) must wait for
+// RunConsolePrinter to return rather than unsubscribing and exiting
+// immediately, since select does not prefer sub.Ch over a closed sub.Quit.
+func RunConsolePrinter(sub *Subscriber) {
+	for {
+		select {
+		case event := <-sub.Ch:
+			fmt.Printf("[event] %T %+v\n", event, event)
+		case <-sub.Quit:
+			drainConsole(sub.Ch)
+			return
+		}
+	}
+}
+
+// drainConsole prints whatever events are already buffered on ch without
+// blocking, so a subscriber that just saw its Quit channel close doesn't
+// silently drop events that were published just before it.
+func drainConsole(ch <-chan interface{}) {
+	for {
+		select {
+		case event := <-ch:
+			fmt.Printf("[event] %T %+v\n", event, event)
+		default:
+			return
+		}
+	}
+}
+
+// RunJSONLinesLogger writes each event received on sub to w as one JSON
+// object per line, until sub.Quit is closed.
+func RunJSONLinesLogger(sub *Subscriber, w io.Writer) {
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case event := <-sub.Ch:
+			_ = enc.Encode(map[string]interface{}{
+				"type":  fmt.Sprintf("%T", event),
+				"event": event,
+			})
+		case <-sub.Quit:
+			return
+		}
+	}
+}
+
+// CounterExporter counts events by their Go type and serves the counts in
+// Prometheus text exposition format.
+type CounterExporter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewCounterExporter returns an empty CounterExporter.
+func NewCounterExporter() *CounterExporter {
+	return &CounterExporter{counts: make(map[string]int)}
+}
+
+// Run consumes events from sub, incrementing the per-type counter, until
+// sub.Quit is closed.
+func (c *CounterExporter) Run(sub *Subscriber) {
+	for {
+		select {
+		case event := <-sub.Ch:
+			eventType := fmt.Sprintf("%T", event)
+			c.mu.Lock()
+			c.counts[eventType]++
+			c.mu.Unlock()
+		case <-sub.Quit:
+			return
+		}
+	}
+}
+
+// ServeHTTP writes the current counters in Prometheus text exposition
+// format, suitable for mounting at e.g. "/metrics".
+func (c *CounterExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintln(w, "# TYPE networks_course_events_total counter")
+	for eventType, count := range c.counts {
+		fmt.Fprintf(w, "networks_course_events_total{type=%q} %d\n", eventType, count)
+	}
+}
+
+// ListenAndServe mounts the exporter at /metrics and blocks serving HTTP on
+// addr (e.g. ":2112").
+func (c *CounterExporter) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,98 @@
+// Package export serializes a simulation's current topology and routing
+// tables into formats meant for humans and other tools rather than a
+// terminal: GraphViz DOT (WriteDOT) and machine-readable JSON (WriteJSON).
+// It knows nothing about lab11's integer node IDs or lab12's IP-addressed
+// routers — callers convert their own state into a Snapshot first, which is
+// why every ID in this package is already a string.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Node is one router/node in a topology snapshot.
+type Node struct {
+	ID string `json:"id"`
+}
+
+// Edge is one link in the topology and the cost of crossing it. Edges are
+// undirected: a link is listed once regardless of which side discovered it.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Cost int    `json:"cost"`
+}
+
+// Route is one entry in a node's routing table.
+type Route struct {
+	Destination string   `json:"destination"`
+	NextHop     string   `json:"next_hop"`
+	Metric      int      `json:"metric"`
+	Path        []string `json:"path,omitempty"`
+}
+
+// Snapshot is everything export needs to render one simulation iteration:
+// the topology (Nodes/Edges) plus every node's routing table, keyed by
+// node ID.
+type Snapshot struct {
+	Iteration int                `json:"iteration"`
+	Nodes     []Node             `json:"nodes"`
+	Edges     []Edge             `json:"edges"`
+	Routes    map[string][]Route `json:"routes"`
+}
+
+// WriteJSON writes snap to w as a single indented JSON object. Call once per
+// iteration (with a fresh file per call) to build up a sequence of
+// per-iteration snapshots.
+func WriteJSON(w io.Writer, snap Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+// WriteDOT writes snap as a GraphViz DOT graph: every link labeled with its
+// cost, and the links making up rootID's shortest-path tree (the first hop
+// out of rootID toward each of its routes) colored red so they stand out
+// from the rest of the topology.
+func WriteDOT(w io.Writer, snap Snapshot, rootID string) error {
+	treeEdges := shortestPathTreeEdges(snap, rootID)
+
+	fmt.Fprintln(w, "graph topology {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, n := range snap.Nodes {
+		label := n.ID
+		if n.ID == rootID {
+			label = fmt.Sprintf("%s (root)", n.ID)
+		}
+		fmt.Fprintf(w, "  %q [label=%q];\n", n.ID, label)
+	}
+	for _, e := range snap.Edges {
+		color := "black"
+		if treeEdges[edgeKey(e.From, e.To)] || treeEdges[edgeKey(e.To, e.From)] {
+			color = "red"
+		}
+		fmt.Fprintf(w, "  %q -- %q [label=%q, color=%q];\n", e.From, e.To, fmt.Sprintf("%d", e.Cost), color)
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func edgeKey(a, b string) string {
+	return a + "->" + b
+}
+
+// shortestPathTreeEdges returns the set of directed edges (as "from->to"
+// keys) on rootID's shortest-path tree: the first hop out of rootID toward
+// every destination in its routing table.
+func shortestPathTreeEdges(snap Snapshot, rootID string) map[string]bool {
+	edges := make(map[string]bool)
+	for _, route := range snap.Routes[rootID] {
+		if route.NextHop == "" || route.NextHop == rootID {
+			continue
+		}
+		edges[edgeKey(rootID, route.NextHop)] = true
+	}
+	return edges
+}
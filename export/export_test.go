@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testSnapshot() Snapshot {
+	return Snapshot{
+		Iteration: 3,
+		Nodes:     []Node{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+		Edges: []Edge{
+			{From: "A", To: "B", Cost: 1},
+			{From: "B", To: "C", Cost: 2},
+		},
+		Routes: map[string][]Route{
+			"A": {
+				{Destination: "B", NextHop: "B", Metric: 1, Path: []string{"B"}},
+				{Destination: "C", NextHop: "B", Metric: 3, Path: []string{"B", "C"}},
+			},
+		},
+	}
+}
+
+func TestWriteDOTColorsRootsShortestPathTree(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, testSnapshot(), "A"); err != nil {
+		t.Fatalf("WriteDOT returned error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"A" -- "B" [label="1", color="red"]`) {
+		t.Fatalf("expected the A-B edge (on A's shortest-path tree) to be red, got:\n%s", out)
+	}
+	if !strings.Contains(out, `"B" -- "C" [label="2", color="black"]`) {
+		t.Fatalf("expected the B-C edge (not A's first hop) to stay black, got:\n%s", out)
+	}
+}
+
+func TestWriteJSONRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	snap := testSnapshot()
+	if err := WriteJSON(&buf, snap); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"iteration": 3`) {
+		t.Fatalf("expected the encoded JSON to carry the iteration number, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"destination": "C"`) {
+		t.Fatalf("expected the encoded JSON to carry route entries, got:\n%s", buf.String())
+	}
+}
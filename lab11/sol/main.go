@@ -1,191 +1,448 @@
-package main
-
-import (
-	"fmt"
-	"math"
-	"sort"
-)
-
-const INFINITY = math.MaxInt32
-
-type RouteInfo struct {
-	Cost    int
-	NextHop int
-}
-
-type Node struct {
-	ID             int
-	NetworkSize    int
-	DistanceVector map[int]RouteInfo
-	Neighbors      map[int]int
-	AdvertisedDV   map[int]RouteInfo
-}
-
-func NewNode(id int, networkSize int) *Node {
-	dv := make(map[int]RouteInfo)
-	for i := 0; i < networkSize; i++ {
-		dv[i] = RouteInfo{Cost: INFINITY, NextHop: -1}
-	}
-	dv[id] = RouteInfo{Cost: 0, NextHop: id}
-
-	return &Node{
-		ID:             id,
-		NetworkSize:    networkSize,
-		DistanceVector: dv,
-		Neighbors:      make(map[int]int),
-	}
-}
-
-func (n *Node) AddNeighbor(neighborID int, cost int) {
-	n.Neighbors[neighborID] = cost
-	n.DistanceVector[neighborID] = RouteInfo{Cost: cost, NextHop: neighborID}
-}
-
-func (n *Node) UpdateDistanceVector(neighborID int, receivedDV map[int]RouteInfo) bool {
-	changed := false
-	costToNeighbor, ok := n.Neighbors[neighborID]
-	if !ok {
-		return false
-	}
-
-	for destNodeID := 0; destNodeID < n.NetworkSize; destNodeID++ {
-		routeInfoFromNeighbor, neighborKnowsRoute := receivedDV[destNodeID]
-		if !neighborKnowsRoute {
-			routeInfoFromNeighbor = RouteInfo{Cost: INFINITY, NextHop: -1}
-		}
-
-		var newCostViaNeighbor int
-		if routeInfoFromNeighbor.Cost == INFINITY {
-			newCostViaNeighbor = INFINITY
-		} else {
-			if costToNeighbor > INFINITY-routeInfoFromNeighbor.Cost {
-				newCostViaNeighbor = INFINITY
-			} else {
-				newCostViaNeighbor = costToNeighbor + routeInfoFromNeighbor.Cost
-			}
-		}
-
-		if routeInfoFromNeighbor.NextHop == n.ID && destNodeID != n.ID {
-			continue
-		}
-
-		currentRouteToDest := n.DistanceVector[destNodeID]
-
-		if newCostViaNeighbor < currentRouteToDest.Cost {
-			n.DistanceVector[destNodeID] = RouteInfo{Cost: newCostViaNeighbor, NextHop: neighborID}
-			changed = true
-		} else if currentRouteToDest.NextHop == neighborID && newCostViaNeighbor > currentRouteToDest.Cost {
-			n.DistanceVector[destNodeID] = RouteInfo{Cost: newCostViaNeighbor, NextHop: neighborID}
-			changed = true
-		}
-	}
-	return changed
-}
-
-func (n *Node) PrepareAdvertisedDV() {
-	n.AdvertisedDV = make(map[int]RouteInfo)
-	for dest, info := range n.DistanceVector {
-		n.AdvertisedDV[dest] = info
-	}
-}
-
-func (n *Node) PrintRoutingTable() {
-	header := fmt.Sprintf("Узел %d", n.ID)
-	fmt.Printf("\n%s:\n", header)
-	fmt.Println("-----------------------------------------")
-	fmt.Println("| Пункт назн. | Стоимость | Следующий узел |")
-	fmt.Println("-----------------------------------------")
-
-	var destIDs []int
-	for id := range n.DistanceVector {
-		destIDs = append(destIDs, id)
-	}
-	sort.Ints(destIDs)
-
-	for _, destID := range destIDs {
-		routeInfo := n.DistanceVector[destID]
-		costStr := "INF"
-		if routeInfo.Cost != INFINITY {
-			costStr = fmt.Sprintf("%d", routeInfo.Cost)
-		}
-		nextHopStr := "-"
-		if routeInfo.NextHop != -1 && routeInfo.Cost != INFINITY {
-			nextHopStr = fmt.Sprintf("%d", routeInfo.NextHop)
-		}
-		if routeInfo.Cost == 0 && routeInfo.NextHop == n.ID {
-			nextHopStr = fmt.Sprintf("%d", n.ID)
-		}
-		fmt.Printf("|      %d         | %-9s |       %-10s |\n", destID, costStr, nextHopStr)
-	}
-	fmt.Println("-----------------------------------------")
-}
-
-type Link struct {
-	U, V, Cost int
-}
-
-var nodes []*Node
-
-func SetupNetwork(numNodes int, linksConfig []Link) {
-	nodes = make([]*Node, numNodes)
-	for i := 0; i < numNodes; i++ {
-		nodes[i] = NewNode(i, numNodes)
-	}
-
-	for _, link := range linksConfig {
-		nodes[link.U].AddNeighbor(link.V, link.Cost)
-		nodes[link.V].AddNeighbor(link.U, link.Cost)
-	}
-}
-
-func RunDistanceVectorSimulation(maxIterations int) {
-	for i := 0; i < maxIterations; i++ {
-		for _, node := range nodes {
-			node.PrepareAdvertisedDV()
-		}
-		anyTableChangedInIteration := false
-		for _, node := range nodes {
-			nodeChangedItsTable := false
-			for neighborID := range node.Neighbors {
-				receivedDV := nodes[neighborID].AdvertisedDV
-				if node.UpdateDistanceVector(neighborID, receivedDV) {
-					nodeChangedItsTable = true
-				}
-			}
-			if nodeChangedItsTable {
-				anyTableChangedInIteration = true
-			}
-		}
-		if !anyTableChangedInIteration {
-			break
-		}
-		if i == maxIterations-1 {
-			break;
-		}
-	}
-
-	fmt.Println("\n--- Финальное состояние таблиц маршрутизации ---")
-	for _, node := range nodes {
-		node.PrintRoutingTable()
-	}
-}
-
-func main() {
-	const NUM_NODES = 4
-
-	initialLinks := []Link{
-		{0, 1, 1},
-		{0, 2, 3},
-		{0, 3, 7},
-		{1, 2, 1},
-		{2, 3, 2},
-	}
-	for i, link := range initialLinks { //
-		if (link.U == 0 && link.V == 3) || (link.U == 3 && link.V == 0) {
-			initialLinks[i].Cost = 1
-			break
-		}
-	} //Эти 5 строк на задание B.
-	SetupNetwork(NUM_NODES, initialLinks)
-	RunDistanceVectorSimulation(10)
-}
\ No newline at end of file
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/RastorguevDanila/networks-course/eventbus"
+)
+
+const INFINITY = math.MaxInt32
+
+// DefaultHoldDownIterations is how many iterations a destination stays in
+// hold-down after it becomes unreachable through its current next hop.
+const DefaultHoldDownIterations = 3
+
+// DefaultMaxPathLength is how many hops a path-vector route may carry before
+// UpdateDistanceVector treats it as unreachable rather than install it.
+// Exported as a var (not a const) so a caller/test can tighten or loosen the
+// cap, the same way HoldDownIterations is a per-Node var seeded from
+// DefaultHoldDownIterations.
+const DefaultMaxPathLength = 16
+
+var MaxPathLength = DefaultMaxPathLength
+
+// RouteInfo is this node's best known route to a destination. Path lists
+// every node the route traverses after this node's next hop, ending at the
+// destination itself (so Path's length is Cost's hop count minus one). It is
+// what makes BGP-style loop detection possible: UpdateDistanceVector rejects
+// any advertised route whose Path already contains this node's own ID.
+type RouteInfo struct {
+	Cost    int
+	NextHop int
+	Path    []int
+}
+
+// holdDownState remembers what a destination's route looked like right
+// before it went unreachable, so that once the hold-down expires (or a
+// qualifying update arrives early) we know what counts as "better".
+type holdDownState struct {
+	expiresAtIteration int
+	oldNextHop         int
+	oldCost            int
+}
+
+type Node struct {
+	ID                 int
+	NetworkSize        int
+	DistanceVector     map[int]RouteInfo
+	Neighbors          map[int]int
+	AdvertisedDV       map[int]RouteInfo
+	HoldDownIterations int
+	holdDown           map[int]holdDownState
+}
+
+func NewNode(id int, networkSize int) *Node {
+	dv := make(map[int]RouteInfo)
+	for i := 0; i < networkSize; i++ {
+		dv[i] = RouteInfo{Cost: INFINITY, NextHop: -1}
+	}
+	dv[id] = RouteInfo{Cost: 0, NextHop: id}
+
+	return &Node{
+		ID:                 id,
+		NetworkSize:        networkSize,
+		DistanceVector:     dv,
+		Neighbors:          make(map[int]int),
+		HoldDownIterations: DefaultHoldDownIterations,
+		holdDown:           make(map[int]holdDownState),
+	}
+}
+
+func (n *Node) AddNeighbor(neighborID int, cost int) {
+	n.Neighbors[neighborID] = cost
+	info := RouteInfo{Cost: cost, NextHop: neighborID, Path: []int{neighborID}}
+	n.DistanceVector[neighborID] = info
+	publishRouteChange(n.ID, neighborID, false, 0, info)
+}
+
+// containsInt reports whether id appears anywhere in path.
+func containsInt(path []int, id int) bool {
+	for _, p := range path {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+func pathEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// betterPath breaks a tie between two equal-cost routes to the same
+// destination: the shorter path wins, and if they're the same length the
+// lexicographically smaller one wins. The rule only needs to be consistent,
+// not meaningful, since its entire job is to stop two nodes from flapping
+// between two equally good routes forever.
+func betterPath(a, b []int) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// publishRouteChange publishes the right structured event for a routing
+// table change to destID on node nodeID: RouteInstalled the first time
+// destID is learned, RouteWithdrawn once it becomes unreachable, and
+// MetricChanged for any other change to its cost.
+func publishRouteChange(nodeID, destID int, oldExists bool, oldCost int, newInfo RouteInfo) {
+	routerID := strconv.Itoa(nodeID)
+	dest := strconv.Itoa(destID)
+	switch {
+	case !oldExists:
+		eventbus.Publish(eventbus.RouteInstalledType, eventbus.RouteInstalled{
+			RouterID: routerID, Destination: dest, NextHop: strconv.Itoa(newInfo.NextHop), Metric: newInfo.Cost,
+		})
+	case newInfo.Cost == INFINITY && oldCost != INFINITY:
+		eventbus.Publish(eventbus.RouteWithdrawnType, eventbus.RouteWithdrawn{RouterID: routerID, Destination: dest})
+	case newInfo.Cost != oldCost:
+		eventbus.Publish(eventbus.MetricChangedType, eventbus.MetricChanged{
+			RouterID: routerID, Destination: dest, OldMetric: oldCost, NewMetric: newInfo.Cost,
+		})
+	}
+}
+
+// startHoldDown records the route a destination had right before it became
+// unreachable, and blocks new routes to it (see UpdateDistanceVector) until
+// HoldDownIterations pass, unless a qualifying update arrives sooner.
+func (n *Node) startHoldDown(destNodeID int, currentIteration int) {
+	old := n.DistanceVector[destNodeID]
+	n.holdDown[destNodeID] = holdDownState{
+		expiresAtIteration: currentIteration + n.HoldDownIterations,
+		oldNextHop:         old.NextHop,
+		oldCost:            old.Cost,
+	}
+}
+
+// SetLinkCost updates the cost of the link to neighborID, for example to
+// simulate a link failure (INFINITY) or repair. When a link to neighborID
+// fails, every route currently using it as next hop is poisoned immediately
+// (route poisoning) instead of waiting to age out via the neighbor's own
+// advertisements, and a hold-down is started for each poisoned destination.
+func (n *Node) SetLinkCost(neighborID int, newCost int, currentIteration int) bool {
+	changed := false
+	n.Neighbors[neighborID] = newCost
+
+	if newCost == INFINITY {
+		eventbus.Publish(eventbus.NeighborLostType, eventbus.NeighborLost{
+			RouterID: strconv.Itoa(n.ID), NeighborID: strconv.Itoa(neighborID),
+		})
+		for destNodeID, info := range n.DistanceVector {
+			if destNodeID != n.ID && info.NextHop == neighborID && info.Cost != INFINITY {
+				n.startHoldDown(destNodeID, currentIteration)
+				newInfo := RouteInfo{Cost: INFINITY, NextHop: -1}
+				n.DistanceVector[destNodeID] = newInfo
+				publishRouteChange(n.ID, destNodeID, true, info.Cost, newInfo)
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	direct := n.DistanceVector[neighborID]
+	if direct.NextHop == neighborID || direct.Cost == INFINITY {
+		if newCost < direct.Cost {
+			newInfo := RouteInfo{Cost: newCost, NextHop: neighborID, Path: []int{neighborID}}
+			n.DistanceVector[neighborID] = newInfo
+			delete(n.holdDown, neighborID)
+			publishRouteChange(n.ID, neighborID, true, direct.Cost, newInfo)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// UpdateDistanceVector applies a received distance vector from neighborID.
+// currentIteration identifies the simulation step, used to evaluate
+// hold-down expiry.
+func (n *Node) UpdateDistanceVector(neighborID int, receivedDV map[int]RouteInfo, currentIteration int) bool {
+	changed := false
+	costToNeighbor, ok := n.Neighbors[neighborID]
+	if !ok {
+		return false
+	}
+
+	for destNodeID := 0; destNodeID < n.NetworkSize; destNodeID++ {
+		if destNodeID == n.ID {
+			continue
+		}
+
+		routeInfoFromNeighbor, neighborKnowsRoute := receivedDV[destNodeID]
+		if !neighborKnowsRoute {
+			routeInfoFromNeighbor = RouteInfo{Cost: INFINITY, NextHop: -1}
+		}
+
+		var newCostViaNeighbor int
+		if routeInfoFromNeighbor.Cost == INFINITY {
+			newCostViaNeighbor = INFINITY
+		} else if costToNeighbor > INFINITY-routeInfoFromNeighbor.Cost {
+			newCostViaNeighbor = INFINITY
+		} else {
+			newCostViaNeighbor = costToNeighbor + routeInfoFromNeighbor.Cost
+		}
+
+		fullPath := append([]int{neighborID}, routeInfoFromNeighbor.Path...)
+		if containsInt(routeInfoFromNeighbor.Path, n.ID) || len(fullPath) > MaxPathLength {
+			// BGP-style loop detection: the advertised path already passes
+			// through us, or would grow past MaxPathLength. Treat the route
+			// as unreachable instead of installing it, which rules out
+			// count-to-infinity outright rather than just slowing it down.
+			newCostViaNeighbor = INFINITY
+			fullPath = nil
+		}
+
+		currentRouteToDest := n.DistanceVector[destNodeID]
+
+		if hd, inHoldDown := n.holdDown[destNodeID]; inHoldDown && currentIteration < hd.expiresAtIteration {
+			sameNextHopAsBefore := neighborID == hd.oldNextHop
+			strictlyBetterThanBefore := newCostViaNeighbor < hd.oldCost
+			if !sameNextHopAsBefore && !strictlyBetterThanBefore {
+				continue
+			}
+		}
+
+		installedFromThisNeighbor := currentRouteToDest.NextHop == neighborID
+		tiesAndWins := newCostViaNeighbor == currentRouteToDest.Cost && !installedFromThisNeighbor && betterPath(fullPath, currentRouteToDest.Path)
+
+		if newCostViaNeighbor < currentRouteToDest.Cost || tiesAndWins {
+			newInfo := RouteInfo{Cost: newCostViaNeighbor, NextHop: neighborID, Path: fullPath}
+			n.DistanceVector[destNodeID] = newInfo
+			delete(n.holdDown, destNodeID)
+			publishRouteChange(n.ID, destNodeID, true, currentRouteToDest.Cost, newInfo)
+			changed = true
+		} else if installedFromThisNeighbor && newCostViaNeighbor > currentRouteToDest.Cost {
+			if newCostViaNeighbor == INFINITY {
+				n.startHoldDown(destNodeID, currentIteration)
+			}
+			newInfo := RouteInfo{Cost: newCostViaNeighbor, NextHop: neighborID, Path: fullPath}
+			n.DistanceVector[destNodeID] = newInfo
+			publishRouteChange(n.ID, destNodeID, true, currentRouteToDest.Cost, newInfo)
+			changed = true
+		} else if installedFromThisNeighbor && newCostViaNeighbor == currentRouteToDest.Cost && !pathEqual(fullPath, currentRouteToDest.Path) {
+			newInfo := RouteInfo{Cost: newCostViaNeighbor, NextHop: neighborID, Path: fullPath}
+			n.DistanceVector[destNodeID] = newInfo
+			publishRouteChange(n.ID, destNodeID, true, currentRouteToDest.Cost, newInfo)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// PrepareAdvertisedDV snapshots this node's current table so that every
+// neighbor sees the same view for this iteration, regardless of the order
+// in which neighbors are processed.
+func (n *Node) PrepareAdvertisedDV() {
+	n.AdvertisedDV = make(map[int]RouteInfo)
+	for dest, info := range n.DistanceVector {
+		n.AdvertisedDV[dest] = info
+	}
+}
+
+// PrepareAdvertisedDVFor returns the view of AdvertisedDV that this node
+// sends to neighborID specifically: split horizon with poisoned reverse.
+// Any route that n would route back through neighborID is advertised as
+// INFINITY instead of its real cost (or being silently omitted), so that
+// neighborID never mistakes its own route back for an independent one.
+func (n *Node) PrepareAdvertisedDVFor(neighborID int) map[int]RouteInfo {
+	adv := make(map[int]RouteInfo, len(n.AdvertisedDV))
+	for dest, info := range n.AdvertisedDV {
+		if dest != n.ID && info.NextHop == neighborID {
+			adv[dest] = RouteInfo{Cost: INFINITY, NextHop: -1}
+		} else {
+			adv[dest] = info
+		}
+	}
+	return adv
+}
+
+type Link struct {
+	U, V, Cost int
+}
+
+// TopologyEventType is the kind of change a TopologyEvent applies to the
+// network while a simulation is running.
+type TopologyEventType string
+
+const (
+	EventLinkUp         TopologyEventType = "link_up"
+	EventLinkDown       TopologyEventType = "link_down"
+	EventLinkCostChange TopologyEventType = "link_cost_change"
+	EventNodeDown       TopologyEventType = "node_down"
+)
+
+// TopologyEvent schedules a link/node change at a given iteration of
+// RunDistanceVectorSimulation. U and V are node IDs; V and Cost are unused
+// for EventNodeDown.
+type TopologyEvent struct {
+	Step int
+	Type TopologyEventType
+	U, V int
+	Cost int
+}
+
+var nodes []*Node
+
+func hasFutureEvent(events []TopologyEvent, step int) bool {
+	for _, ev := range events {
+		if ev.Step > step {
+			return true
+		}
+	}
+	return false
+}
+
+func applyTopologyEventsForStep(events []TopologyEvent, step int) {
+	for _, ev := range events {
+		if ev.Step == step {
+			applyTopologyEvent(ev, step)
+		}
+	}
+}
+
+func applyTopologyEvent(ev TopologyEvent, currentIteration int) {
+	switch ev.Type {
+	case EventLinkDown:
+		setLinkCostBothWays(ev.U, ev.V, INFINITY, currentIteration)
+	case EventLinkUp, EventLinkCostChange:
+		setLinkCostBothWays(ev.U, ev.V, ev.Cost, currentIteration)
+	case EventNodeDown:
+		for neighborID := range nodes[ev.U].Neighbors {
+			setLinkCostBothWays(ev.U, neighborID, INFINITY, currentIteration)
+		}
+	}
+}
+
+func setLinkCostBothWays(u, v, cost, currentIteration int) {
+	nodes[u].SetLinkCost(v, cost, currentIteration)
+	nodes[v].SetLinkCost(u, cost, currentIteration)
+}
+
+func SetupNetwork(numNodes int, linksConfig []Link) {
+	nodes = make([]*Node, numNodes)
+	for i := 0; i < numNodes; i++ {
+		nodes[i] = NewNode(i, numNodes)
+	}
+
+	for _, link := range linksConfig {
+		nodes[link.U].AddNeighbor(link.V, link.Cost)
+		nodes[link.V].AddNeighbor(link.U, link.Cost)
+	}
+}
+
+// RunDistanceVectorSimulation runs the lock-step DV algorithm for up to
+// maxIterations. Because every node re-advertises its table on every
+// iteration, poisoning and other triggered changes already propagate on the
+// very next iteration without any separate triggered-update path.
+// onIteration, if non-nil, is called once per completed iteration with that
+// iteration's number, letting a caller snapshot the network's state as the
+// simulation progresses instead of only at the end.
+func RunDistanceVectorSimulation(maxIterations int, events []TopologyEvent, onIteration func(iteration int)) {
+	lastIteration := 0
+	for i := 0; i < maxIterations; i++ {
+		applyTopologyEventsForStep(events, i)
+
+		for _, node := range nodes {
+			node.PrepareAdvertisedDV()
+		}
+		anyTableChangedInIteration := false
+		for _, node := range nodes {
+			nodeChangedItsTable := false
+			for neighborID := range node.Neighbors {
+				receivedDV := nodes[neighborID].PrepareAdvertisedDVFor(node.ID)
+				if node.UpdateDistanceVector(neighborID, receivedDV, i) {
+					nodeChangedItsTable = true
+				}
+			}
+			if nodeChangedItsTable {
+				anyTableChangedInIteration = true
+			}
+		}
+		if onIteration != nil {
+			onIteration(i + 1)
+		}
+		if !anyTableChangedInIteration && !hasFutureEvent(events, i) {
+			break
+		}
+		if i == maxIterations-1 {
+			break
+		}
+		lastIteration = i
+	}
+
+	eventbus.Publish(eventbus.ConvergenceReachedType, eventbus.ConvergenceReached{Iteration: lastIteration})
+}
+
+func main() {
+	const NUM_NODES = 4
+
+	initialLinks := []Link{
+		{0, 1, 1},
+		{0, 2, 3},
+		{0, 3, 7},
+		{1, 2, 1},
+		{2, 3, 2},
+	}
+	SetupNetwork(NUM_NODES, initialLinks)
+
+	console := eventbus.Subscribe("")
+	consoleDone := make(chan struct{})
+	go func() {
+		eventbus.RunConsolePrinter(console)
+		close(consoleDone)
+	}()
+	defer func() {
+		eventbus.Unsubscribe(console)
+		<-consoleDone
+	}()
+
+	// snapshotIteration writes one topology.<N>.dot/.json pair per
+	// iteration, so the tui command can load and step through the whole run
+	// rather than only its final state.
+	snapshotIteration := func(iteration int) {
+		dotPath := fmt.Sprintf("topology.%d.dot", iteration)
+		jsonPath := fmt.Sprintf("topology.%d.json", iteration)
+		if err := writeSnapshotFiles(iteration, dotPath, jsonPath); err != nil {
+			fmt.Printf("Warning: could not write topology snapshot for iteration %d: %v\n", iteration, err)
+		}
+	}
+
+	// Задание B: на 5-й итерации стоимость линка 0-3 падает с 7 до 1.
+	RunDistanceVectorSimulation(10, []TopologyEvent{
+		{Step: 5, Type: EventLinkCostChange, U: 0, V: 3, Cost: 1},
+	}, snapshotIteration)
+}
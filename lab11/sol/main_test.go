@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestPrepareAdvertisedDVForPoisonsRouteBackToNextHop(t *testing.T) {
+	n := NewNode(1, 4)
+	n.AddNeighbor(0, 1)
+	n.AddNeighbor(3, 1)
+	n.DistanceVector[2] = RouteInfo{Cost: 2, NextHop: 0} // best route to 2 goes via neighbor 0
+	n.PrepareAdvertisedDV()
+
+	advToNeighbor0 := n.PrepareAdvertisedDVFor(0)
+	if advToNeighbor0[2].Cost != INFINITY {
+		t.Fatalf("expected route to 2 advertised to neighbor 0 to be poisoned, got cost %d", advToNeighbor0[2].Cost)
+	}
+
+	advToNeighbor3 := n.PrepareAdvertisedDVFor(3)
+	if advToNeighbor3[2].Cost != 2 {
+		t.Fatalf("expected route to 2 advertised to neighbor 3 to be untouched, got cost %d", advToNeighbor3[2].Cost)
+	}
+}
+
+func TestLinkFailureConvergesToInfinityWithoutCountingUp(t *testing.T) {
+	SetupNetwork(3, []Link{{0, 1, 1}, {1, 2, 1}})
+	RunDistanceVectorSimulation(10, nil, nil)
+
+	if cost := nodes[0].DistanceVector[2].Cost; cost != 2 {
+		t.Fatalf("expected node 0 to reach node 2 at cost 2 before the failure, got %d", cost)
+	}
+
+	iteration := 10
+	nodes[1].SetLinkCost(2, INFINITY, iteration)
+	nodes[2].SetLinkCost(1, INFINITY, iteration)
+
+	for step := 0; step < 20; step++ {
+		iteration++
+		for _, node := range nodes {
+			node.PrepareAdvertisedDV()
+		}
+		for _, node := range nodes {
+			for neighborID := range node.Neighbors {
+				receivedDV := nodes[neighborID].PrepareAdvertisedDVFor(node.ID)
+				node.UpdateDistanceVector(neighborID, receivedDV, iteration)
+			}
+		}
+
+		if cost := nodes[0].DistanceVector[2].Cost; cost != INFINITY && cost > 2 {
+			t.Fatalf("count-to-infinity detected at step %d: node 0's cost to node 2 climbed to %d", step, cost)
+		}
+	}
+
+	if cost := nodes[0].DistanceVector[2].Cost; cost != INFINITY {
+		t.Fatalf("expected node 0's route to node 2 to converge to INFINITY, got %d", cost)
+	}
+	if cost := nodes[1].DistanceVector[2].Cost; cost != INFINITY {
+		t.Fatalf("expected node 1's route to node 2 to converge to INFINITY, got %d", cost)
+	}
+}
+
+func TestScheduledLinkDownEventIsAppliedAtItsStep(t *testing.T) {
+	SetupNetwork(3, []Link{{0, 1, 1}, {1, 2, 1}})
+	RunDistanceVectorSimulation(5, []TopologyEvent{
+		{Step: 3, Type: EventLinkDown, U: 1, V: 2},
+	}, nil)
+
+	if cost := nodes[0].DistanceVector[2].Cost; cost != INFINITY {
+		t.Fatalf("expected node 0's route to node 2 to be INFINITY after the scheduled link-down event, got %d", cost)
+	}
+	if _, stillNeighbors := nodes[1].Neighbors[2]; !stillNeighbors {
+		t.Fatalf("expected nodes[1].Neighbors[2] to remain present but poisoned, not removed")
+	}
+	if cost := nodes[1].Neighbors[2]; cost != INFINITY {
+		t.Fatalf("expected the link cost between node 1 and node 2 to be INFINITY, got %d", cost)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/RastorguevDanila/networks-course/export"
+)
+
+// buildSnapshot converts the live simulation state into the neutral
+// export.Snapshot the export package renders to DOT/JSON: every node
+// (rendered by its integer ID as a string), every link listed once
+// regardless of which side's Neighbors it was read from, and everyone's
+// current distance vector.
+func buildSnapshot(iteration int) export.Snapshot {
+	snap := export.Snapshot{
+		Iteration: iteration,
+		Routes:    make(map[string][]export.Route, len(nodes)),
+	}
+
+	seenEdge := make(map[string]bool)
+	for _, n := range nodes {
+		id := strconv.Itoa(n.ID)
+		snap.Nodes = append(snap.Nodes, export.Node{ID: id})
+
+		for neighborID, cost := range n.Neighbors {
+			a, b := n.ID, neighborID
+			if a > b {
+				a, b = b, a
+			}
+			key := strconv.Itoa(a) + "|" + strconv.Itoa(b)
+			if seenEdge[key] {
+				continue
+			}
+			seenEdge[key] = true
+			snap.Edges = append(snap.Edges, export.Edge{From: strconv.Itoa(a), To: strconv.Itoa(b), Cost: cost})
+		}
+
+		routes := make([]export.Route, 0, len(n.DistanceVector))
+		for destID, info := range n.DistanceVector {
+			if info.Cost == INFINITY {
+				continue
+			}
+			path := make([]string, len(info.Path))
+			for i, hop := range info.Path {
+				path[i] = strconv.Itoa(hop)
+			}
+			routes = append(routes, export.Route{
+				Destination: strconv.Itoa(destID),
+				NextHop:     strconv.Itoa(info.NextHop),
+				Metric:      info.Cost,
+				Path:        path,
+			})
+		}
+		snap.Routes[id] = routes
+	}
+	return snap
+}
+
+// writeSnapshotFiles writes the current simulation state to dotPath and
+// jsonPath, rooting the DOT graph's shortest-path-tree coloring at
+// nodes[0].
+func writeSnapshotFiles(iteration int, dotPath, jsonPath string) error {
+	snap := buildSnapshot(iteration)
+
+	rootID := ""
+	if len(nodes) > 0 {
+		rootID = strconv.Itoa(nodes[0].ID)
+	}
+
+	dotFile, err := os.Create(dotPath)
+	if err != nil {
+		return err
+	}
+	defer dotFile.Close()
+	if err := export.WriteDOT(dotFile, snap, rootID); err != nil {
+		return err
+	}
+
+	jsonFile, err := os.Create(jsonPath)
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+	return export.WriteJSON(jsonFile, snap)
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"container/heap"
+
+	"github.com/RastorguevDanila/networks-course/eventbus"
+)
+
+// MaxLSAAge is how many simulation steps an LSA is kept without being
+// refreshed before it is discarded as stale, forcing it to be relearned.
+const MaxLSAAge = 30
+
+// LSA is one router's link-state advertisement: the full set of its direct
+// neighbors and their costs, tagged with a sequence number so a newer LSA
+// always wins over an older one for the same origin, and an age used to
+// evict an origin's LSA if it is never refreshed.
+type LSA struct {
+	OriginIP  string
+	SeqNum    int
+	Age       int
+	Neighbors map[string]int
+}
+
+// RunLinkStateSimulation runs a link-state engine over the same topology and
+// TopologyEvent schedule as the RIP/DV engines above: every router floods
+// its own LSA, builds a link-state database from what it receives, and runs
+// Dijkstra's algorithm over that database to compute its routing table. It
+// is a lock-step simulation like runSimulationSync, not the goroutine-based
+// RunSimulationAsync, since there is no per-link delay to model here.
+// onIteration, if non-nil, is called once per completed step with that
+// step's number, letting a caller snapshot the network's state as the
+// simulation progresses instead of only at the end.
+func RunLinkStateSimulation(allRouters map[string]*Router, events []TopologyEvent, onIteration func(iteration int)) {
+	for _, r := range allRouters {
+		r.LSDB = make(map[string]LSA)
+		r.RoutingTable = make(map[string]RouteEntry)
+		originateLSA(r)
+	}
+
+	maxSteps := len(allRouters) * 2
+	if maxSteps == 0 {
+		maxSteps = 1
+	} else if maxSteps < 5 && len(allRouters) > 1 {
+		maxSteps = 5
+	}
+
+	lastStep := 0
+	for step := 0; step < maxSteps; step++ {
+		lastStep = step
+		applyTopologyEventsForStep(allRouters, events, step)
+		for _, r := range allRouters {
+			originateLSA(r)
+		}
+
+		changed := ageLSDBs(allRouters)
+		if floodLSAs(allRouters) {
+			changed = true
+		}
+
+		for _, r := range allRouters {
+			runDijkstra(r)
+		}
+
+		if onIteration != nil {
+			onIteration(step + 1)
+		}
+
+		if !changed && step > 0 && !hasFutureEvent(events, step) {
+			break
+		}
+	}
+
+	eventbus.Publish(eventbus.ConvergenceReachedType, eventbus.ConvergenceReached{Iteration: lastStep})
+}
+
+// originateLSA regenerates r's own LSA from its current NeighborIPs/LinkCost
+// and installs it into r's own LSDB, bumping SeqNum (and so forcing a
+// re-flood) only when the neighbor set or costs actually changed.
+func originateLSA(r *Router) {
+	neighbors := make(map[string]int, len(r.NeighborIPs))
+	for _, neighborIP := range r.NeighborIPs {
+		neighbors[neighborIP] = r.costTo(neighborIP)
+	}
+
+	if existing, ok := r.LSDB[r.IP]; ok && sameNeighbors(existing.Neighbors, neighbors) {
+		return
+	}
+
+	r.lsSeqNum++
+	r.LSDB[r.IP] = LSA{OriginIP: r.IP, SeqNum: r.lsSeqNum, Age: 0, Neighbors: neighbors}
+}
+
+func sameNeighbors(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for ip, cost := range a {
+		if b[ip] != cost {
+			return false
+		}
+	}
+	return true
+}
+
+// ageLSDBs increments the age of every LSA not originated this step and
+// discards any that crossed MaxLSAAge without being refreshed, reporting
+// whether any router's database shrank as a result.
+func ageLSDBs(allRouters map[string]*Router) bool {
+	changed := false
+	for _, r := range allRouters {
+		for originIP, lsa := range r.LSDB {
+			if originIP == r.IP {
+				continue
+			}
+			lsa.Age++
+			if lsa.Age > MaxLSAAge {
+				delete(r.LSDB, originIP)
+				changed = true
+				continue
+			}
+			r.LSDB[originIP] = lsa
+		}
+	}
+	return changed
+}
+
+// floodLSAs propagates every router's LSDB entries one hop further along its
+// NeighborIPs, installing an incoming LSA only when it is newer (higher
+// SeqNum) than whatever is already stored for that origin, so stale copies
+// are discarded rather than overwriting fresher state. Run repeatedly
+// (once per simulation step), this reaches every router within the
+// network's diameter in steps.
+func floodLSAs(allRouters map[string]*Router) bool {
+	changed := false
+	for _, r := range allRouters {
+		for _, neighborIP := range r.NeighborIPs {
+			neighbor, ok := allRouters[neighborIP]
+			if !ok {
+				continue
+			}
+			for _, lsa := range r.LSDB {
+				if existing, has := neighbor.LSDB[lsa.OriginIP]; !has || lsa.SeqNum > existing.SeqNum {
+					neighbor.LSDB[lsa.OriginIP] = lsa
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// lsQueueItem is one entry in runDijkstra's priority queue: a candidate IP
+// and its tentative distance from the router running the algorithm.
+type lsQueueItem struct {
+	ip   string
+	dist int
+}
+
+// lsPriorityQueue is a container/heap min-heap over lsQueueItem.dist, giving
+// runDijkstra O((V+E) log V) behaviour over the NetworkSize nodes in a
+// router's LSDB instead of a linear scan for the next closest node.
+type lsPriorityQueue []lsQueueItem
+
+func (pq lsPriorityQueue) Len() int            { return len(pq) }
+func (pq lsPriorityQueue) Less(i, j int) bool  { return pq[i].dist < pq[j].dist }
+func (pq lsPriorityQueue) Swap(i, j int)       { pq[i], pq[j] = pq[j], pq[i] }
+func (pq *lsPriorityQueue) Push(x interface{}) { *pq = append(*pq, x.(lsQueueItem)) }
+func (pq *lsPriorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	*pq = old[:n-1]
+	return item
+}
+
+// runDijkstra rebuilds r's RoutingTable from its current LSDB: shortest
+// distances come from a heap-based Dijkstra over the topology graph implied
+// by every origin's advertised neighbor list, and the next hop for each
+// destination is the first link on that shortest path out of r.
+func runDijkstra(r *Router) {
+	dist := map[string]int{r.IP: 0}
+	nextHop := map[string]string{}
+	path := map[string][]string{}
+	visited := map[string]bool{}
+
+	pq := &lsPriorityQueue{{ip: r.IP, dist: 0}}
+	heap.Init(pq)
+
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(lsQueueItem)
+		if visited[cur.ip] {
+			continue
+		}
+		visited[cur.ip] = true
+
+		lsa, ok := r.LSDB[cur.ip]
+		if !ok {
+			continue
+		}
+		for neighborIP, cost := range lsa.Neighbors {
+			if visited[neighborIP] {
+				continue
+			}
+			newPath := append(append([]string{}, path[cur.ip]...), neighborIP)
+			if len(newPath) > MaxPathLength {
+				continue
+			}
+			newDist := cur.dist + cost
+			if existing, ok := dist[neighborIP]; !ok || newDist < existing {
+				dist[neighborIP] = newDist
+				if cur.ip == r.IP {
+					nextHop[neighborIP] = neighborIP
+				} else {
+					nextHop[neighborIP] = nextHop[cur.ip]
+				}
+				path[neighborIP] = newPath
+				heap.Push(pq, lsQueueItem{ip: neighborIP, dist: newDist})
+			}
+		}
+	}
+
+	newTable := map[string]RouteEntry{r.IP: {DestinationIP: r.IP, NextHopIP: r.IP, Metric: 0}}
+	for destIP, d := range dist {
+		if destIP == r.IP {
+			continue
+		}
+		newTable[destIP] = RouteEntry{DestinationIP: destIP, NextHopIP: nextHop[destIP], Metric: d, Path: path[destIP]}
+	}
+
+	oldTable := r.RoutingTable
+	r.RoutingTable = newTable
+	for destIP, entry := range newTable {
+		old, existed := oldTable[destIP]
+		if existed && old.Metric == entry.Metric && old.NextHopIP == entry.NextHopIP {
+			continue
+		}
+		oldMetric := MAX_METRIC
+		if existed {
+			oldMetric = old.Metric
+		}
+		publishRouteChange(r.IP, destIP, existed, oldMetric, entry)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestRunLinkStateSimulationConverges(t *testing.T) {
+	a := &Router{IP: "a", NeighborIPs: []string{"b"}, LinkCost: map[string]int{"b": 1}}
+	b := &Router{IP: "b", NeighborIPs: []string{"a", "c"}, LinkCost: map[string]int{"a": 1, "c": 1}}
+	c := &Router{IP: "c", NeighborIPs: []string{"b"}, LinkCost: map[string]int{"b": 1}}
+	allRouters := map[string]*Router{"a": a, "b": b, "c": c}
+
+	RunLinkStateSimulation(allRouters, nil, nil)
+
+	entry := a.RoutingTable["c"]
+	if entry.NextHopIP != "b" {
+		t.Fatalf("expected a's route to c to go via b, got nextHop=%s", entry.NextHopIP)
+	}
+	if entry.Metric != 2 {
+		t.Fatalf("expected a's route to c to cost 2, got %d", entry.Metric)
+	}
+}
+
+func TestRunLinkStateSimulationReroutesAroundLinkDownMidRun(t *testing.T) {
+	a := &Router{IP: "a", NeighborIPs: []string{"b", "c"}, LinkCost: map[string]int{"b": 1, "c": 1}}
+	b := &Router{IP: "b", NeighborIPs: []string{"a", "c"}, LinkCost: map[string]int{"a": 1, "c": 1}}
+	c := &Router{IP: "c", NeighborIPs: []string{"a", "b"}, LinkCost: map[string]int{"a": 1, "b": 1}}
+	allRouters := map[string]*Router{"a": a, "b": b, "c": c}
+
+	events := []TopologyEvent{{Step: 1, Type: EventLinkDown, A: "a", B: "b"}}
+	RunLinkStateSimulation(allRouters, events, nil)
+
+	entry := a.RoutingTable["b"]
+	if entry.NextHopIP != "c" {
+		t.Fatalf("expected a's route to b to reroute via c after the link went down, got nextHop=%s metric=%d", entry.NextHopIP, entry.Metric)
+	}
+}
+
+func TestAgeLSDBsEvictsStaleLSAPastMaxLSAAge(t *testing.T) {
+	r := &Router{IP: "a", LSDB: map[string]LSA{
+		"ghost": {OriginIP: "ghost", SeqNum: 1, Age: 0, Neighbors: map[string]int{"a": 1}},
+	}}
+	allRouters := map[string]*Router{"a": r}
+
+	for i := 0; i <= MaxLSAAge; i++ {
+		ageLSDBs(allRouters)
+	}
+
+	if _, stillPresent := r.LSDB["ghost"]; stillPresent {
+		t.Fatalf("expected the unrefreshed LSA from ghost to be evicted after MaxLSAAge steps")
+	}
+}
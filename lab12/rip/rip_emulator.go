@@ -1,219 +1,601 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
-	"sort"
-)
-
-const MAX_METRIC = 16
-const CONFIG_FILE_NAME = "config.json"
-
-type RouteEntry struct {
-	DestinationIP string
-	NextHopIP     string
-	Metric        int
-}
-
-type Router struct {
-	IP           string
-	RoutingTable map[string]RouteEntry
-	NeighborIPs  []string
-}
-
-type Config struct {
-	Routers []string `json:"routers"`
-	Links   []struct {
-		From string `json:"from"`
-		To   string `json:"to"`
-	} `json:"links"`
-}
-
-
-func printTable(router *Router, title string) {
-	fmt.Printf("\n%s\n", title)
-	fmt.Println("[Source IP]      [Destination IP]    [Next Hop]       [Metric]")
-
-	var destinations []string
-	for dest := range router.RoutingTable {
-		destinations = append(destinations, dest)
-	}
-	sort.Strings(destinations) 
-
-	for _, destIP := range destinations {
-		entry := router.RoutingTable[destIP]
-		nextHopDisplay := entry.NextHopIP
-		if entry.Metric == 0 {
-			nextHopDisplay = router.IP
-		}
-		fmt.Printf("%-15s    %-15s     %-15s     %d\n",
-			router.IP,
-			entry.DestinationIP,
-			nextHopDisplay,
-			entry.Metric)
-	}
-}
-
-func initializeRouterTable(r *Router, allRouters map[string]*Router) {
-	r.RoutingTable = make(map[string]RouteEntry)
-	r.RoutingTable[r.IP] = RouteEntry{DestinationIP: r.IP, NextHopIP: r.IP, Metric: 0}
-	for _, neighborIP := range r.NeighborIPs {
-		if _, exists := allRouters[neighborIP]; exists {
-			r.RoutingTable[neighborIP] = RouteEntry{DestinationIP: neighborIP, NextHopIP: neighborIP, Metric: 1}
-		}
-	}
-}
-
-func uniqueStrings(slice []string) []string {
-	keys := make(map[string]bool)
-	list := []string{}
-	for _, entry := range slice {
-		if _, value := keys[entry]; !value {
-			keys[entry] = true
-			list = append(list, entry)
-		}
-	}
-	return list
-}
-
-func runSimulationSync(allRouters map[string]*Router) {
-	for _, r := range allRouters {
-		initializeRouterTable(r, allRouters)
-	}
-
-	fmt.Println("Initial state of tables (Step 0):")
-	for _, r := range allRouters {
-		printTable(r, fmt.Sprintf("Router %s table:", r.IP))
-	}
-
-	maxSteps := len(allRouters) * 2
-	if maxSteps == 0 {
-		maxSteps = 1
-	} else if maxSteps < 5 && len(allRouters) > 1 {
-		maxSteps = 5
-	}
-
-	for step := 0; step < maxSteps; step++ {
-		changedInThisIteration := false
-		advertisementsToSend := make(map[string][]RouteEntry)
-
-		for _, sender := range allRouters {
-			currentAd := make([]RouteEntry, 0, len(sender.RoutingTable))
-			for _, entry := range sender.RoutingTable {
-				currentAd = append(currentAd, entry)
-			}
-			advertisementsToSend[sender.IP] = currentAd
-		}
-
-		for _, receiver := range allRouters {
-			for _, neighborIP := range receiver.NeighborIPs {
-				if routesFromNeighbor, ok := advertisementsToSend[neighborIP]; ok {
-					for _, advEntry := range routesFromNeighbor {
-
-						if advEntry.DestinationIP == receiver.IP { // маршрут к самому себе
-							continue
-						}
-
-						costViaNeighbor := advEntry.Metric + 1
-						if costViaNeighbor > MAX_METRIC {
-							costViaNeighbor = MAX_METRIC
-						}
-
-						currentEntry, exists := receiver.RoutingTable[advEntry.DestinationIP]
-
-						if !exists && costViaNeighbor < MAX_METRIC { // если маршрута нет и новая стоимость нормальная - создаём
-							receiver.RoutingTable[advEntry.DestinationIP] = RouteEntry{
-								DestinationIP: advEntry.DestinationIP,
-								NextHopIP:     neighborIP,
-								Metric:        costViaNeighbor,
-							}
-							changedInThisIteration = true
-						} else if exists { 
-							if neighborIP == currentEntry.NextHopIP { // маршрут есть но он проходит через того же соседа
-								if costViaNeighbor != currentEntry.Metric {
-									receiver.RoutingTable[advEntry.DestinationIP] = RouteEntry{
-										DestinationIP: advEntry.DestinationIP,
-										NextHopIP:     neighborIP,
-										Metric:        costViaNeighbor,
-									}
-									changedInThisIteration = true
-								}
-							} else { // через другого соседа
-								if costViaNeighbor < currentEntry.Metric {
-									receiver.RoutingTable[advEntry.DestinationIP] = RouteEntry{
-										DestinationIP: advEntry.DestinationIP,
-										NextHopIP:     neighborIP,
-										Metric:        costViaNeighbor,
-									}
-									changedInThisIteration = true
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-
-		fmt.Printf("\n--- Simulation step %d ---\n", step+1)
-		for _, r := range allRouters {
-			printTable(r, fmt.Sprintf("Router %s table:", r.IP))
-		}
-
-		if !changedInThisIteration && step > 0 {
-			fmt.Printf("\n--- Convergence reached after %d iteration(s) ---\n", step+1)
-			break
-		}
-		if step == maxSteps-1 {
-			fmt.Printf("\n--- Max iterations %d reached ---\n", maxSteps)
-		}
-	}
-
-	fmt.Println("\n\n--- Final Routing Tables ---")
-	for _, r := range allRouters {
-		printTable(r, fmt.Sprintf("Final state of router %s table:", r.IP))
-	}
-}
-
-func loadConfigFromFile(filePath string) (map[string]*Router, error) {
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file '%s': %w", filePath, err)
-	}
-
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON from '%s': %w", filePath, err)
-	}
-
-	allRouters := make(map[string]*Router)
-	for _, ipStr := range config.Routers {
-		allRouters[ipStr] = &Router{IP: ipStr, NeighborIPs: []string{}}
-	}
-
-	for _, link := range config.Links {
-		r1, ok1 := allRouters[link.From]
-		r2, ok2 := allRouters[link.To]
-		if ok1 && ok2 {
-			r1.NeighborIPs = append(r1.NeighborIPs, link.To)
-			r2.NeighborIPs = append(r2.NeighborIPs, link.From)
-		} else {
-			log.Printf("Warning: Invalid link %s-%s", link.From, link.To)
-		}
-	}
-	for _, r := range allRouters {
-		r.NeighborIPs = uniqueStrings(r.NeighborIPs)
-	}
-	return allRouters, nil
-}
-
-func main() {
-	allRouters, err := loadConfigFromFile(CONFIG_FILE_NAME)
-	if err != nil {
-		log.Fatalf("Fatal error: Could not load or parse '%s': %v", CONFIG_FILE_NAME, err)
-		os.Exit(1) 
-	}
-	runSimulationSync(allRouters)
-}
\ No newline at end of file
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/RastorguevDanila/networks-course/eventbus"
+)
+
+const MAX_METRIC = 16
+const CONFIG_FILE_NAME = "config.json"
+
+// DefaultMaxPathLength is how many hops a path-vector route may carry
+// before applyAdvertisement treats it as unreachable rather than install
+// it. A var, not a const, so a caller/test can tighten or loosen the cap.
+const DefaultMaxPathLength = 16
+
+var MaxPathLength = DefaultMaxPathLength
+
+// RouteEntry is a router's best known route to a destination. Path lists
+// every router the route traverses after NextHopIP, ending at
+// DestinationIP itself. It is what makes BGP-style loop detection
+// possible: applyAdvertisement rejects any advertised route whose Path
+// already contains the receiving router's own IP.
+type RouteEntry struct {
+	DestinationIP string
+	NextHopIP     string
+	Metric        int
+	Path          []string
+}
+
+type Router struct {
+	IP           string
+	RoutingTable map[string]RouteEntry
+	NeighborIPs  []string
+	LinkCost     map[string]int
+	Inbox        chan Advertisement
+	mu           sync.Mutex
+
+	// LSDB and lsSeqNum are only used by RunLinkStateSimulation (see
+	// linkstate.go); the RIP/DV engines above never touch them.
+	LSDB     map[string]LSA
+	lsSeqNum int
+}
+
+// costTo returns the configured cost of the direct link to neighborIP,
+// defaulting to 1 hop when no TopologyEvent has set anything else.
+func (r *Router) costTo(neighborIP string) int {
+	if r.LinkCost != nil {
+		if cost, ok := r.LinkCost[neighborIP]; ok {
+			return cost
+		}
+	}
+	return 1
+}
+
+// Protocol selects which routing engine a config runs under.
+type Protocol string
+
+const (
+	ProtocolDV Protocol = "dv"
+	ProtocolLS Protocol = "ls"
+)
+
+type Config struct {
+	Protocol Protocol `json:"protocol"`
+	Routers  []string `json:"routers"`
+	Links    []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"links"`
+	Events []TopologyEvent `json:"events"`
+}
+
+// TopologyEventType is the kind of change a TopologyEvent applies to the
+// network while a simulation is running.
+type TopologyEventType string
+
+const (
+	EventLinkUp         TopologyEventType = "link_up"
+	EventLinkDown       TopologyEventType = "link_down"
+	EventLinkCostChange TopologyEventType = "link_cost_change"
+	EventNodeDown       TopologyEventType = "node_down"
+)
+
+// TopologyEvent schedules a link/node change at a given simulation step
+// (the step/round number of RunSimulationAsync, RunLinkStateSimulation, or
+// the DV engine's own loop). A and B are router IPs; B and Cost are unused
+// for EventNodeDown.
+type TopologyEvent struct {
+	Step int               `json:"step"`
+	Type TopologyEventType `json:"type"`
+	A    string            `json:"a"`
+	B    string            `json:"b,omitempty"`
+	Cost int               `json:"cost,omitempty"`
+}
+
+// applyTopologyEvent mutates the live NeighborIPs/LinkCost of the affected
+// routers and invalidates any routing table entries that went through a
+// broken link, so the next advertisement re-announces them as unreachable.
+func applyTopologyEvent(allRouters map[string]*Router, ev TopologyEvent) {
+	switch ev.Type {
+	case EventLinkDown:
+		breakLink(allRouters, ev.A, ev.B)
+	case EventLinkUp:
+		formLink(allRouters, ev.A, ev.B, ev.Cost)
+	case EventLinkCostChange:
+		changeLinkCost(allRouters, ev.A, ev.B, ev.Cost)
+	case EventNodeDown:
+		r, ok := allRouters[ev.A]
+		if !ok {
+			return
+		}
+		for _, neighborIP := range append([]string{}, r.NeighborIPs...) {
+			breakLink(allRouters, ev.A, neighborIP)
+		}
+	default:
+		log.Printf("Warning: unknown topology event type %q", ev.Type)
+	}
+}
+
+func hasFutureEvent(events []TopologyEvent, step int) bool {
+	for _, ev := range events {
+		if ev.Step > step {
+			return true
+		}
+	}
+	return false
+}
+
+func applyTopologyEventsForStep(allRouters map[string]*Router, events []TopologyEvent, step int) {
+	for _, ev := range events {
+		if ev.Step == step {
+			applyTopologyEvent(allRouters, ev)
+		}
+	}
+}
+
+func breakLink(allRouters map[string]*Router, aIP, bIP string) {
+	a, okA := allRouters[aIP]
+	b, okB := allRouters[bIP]
+	if !okA || !okB {
+		return
+	}
+	breakLinkOneSide(a, bIP)
+	breakLinkOneSide(b, aIP)
+}
+
+func breakLinkOneSide(r *Router, otherIP string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.NeighborIPs = removeString(r.NeighborIPs, otherIP)
+
+	// Record the link as unreachable rather than deleting the entry:
+	// costTo's default-cost-1 fallback is for a link that was never
+	// configured, not one that went down, and a stale in-flight
+	// advertisement from otherIP arriving after this (see applyAdvertisement's
+	// neighbor check) must not resurrect it with that default cost.
+	if r.LinkCost == nil {
+		r.LinkCost = make(map[string]int)
+	}
+	r.LinkCost[otherIP] = MAX_METRIC
+
+	eventbus.Publish(eventbus.NeighborLostType, eventbus.NeighborLost{RouterID: r.IP, NeighborID: otherIP})
+
+	for dest, entry := range r.RoutingTable {
+		if dest != r.IP && entry.NextHopIP == otherIP && entry.Metric < MAX_METRIC {
+			oldMetric := entry.Metric
+			entry.Metric = MAX_METRIC
+			r.RoutingTable[dest] = entry
+			publishRouteChange(r.IP, dest, true, oldMetric, entry)
+		}
+	}
+}
+
+func formLink(allRouters map[string]*Router, aIP, bIP string, cost int) {
+	a, okA := allRouters[aIP]
+	b, okB := allRouters[bIP]
+	if !okA || !okB {
+		return
+	}
+	if cost <= 0 {
+		cost = 1
+	}
+	formLinkOneSide(a, bIP, cost)
+	formLinkOneSide(b, aIP, cost)
+}
+
+func formLinkOneSide(r *Router, otherIP string, cost int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.NeighborIPs = uniqueStrings(append(r.NeighborIPs, otherIP))
+	if r.LinkCost == nil {
+		r.LinkCost = make(map[string]int)
+	}
+	r.LinkCost[otherIP] = cost
+
+	oldEntry, oldExists := r.RoutingTable[otherIP]
+	newEntry := RouteEntry{DestinationIP: otherIP, NextHopIP: otherIP, Metric: cost, Path: []string{otherIP}}
+	r.RoutingTable[otherIP] = newEntry
+	publishRouteChange(r.IP, otherIP, oldExists, oldEntry.Metric, newEntry)
+}
+
+func changeLinkCost(allRouters map[string]*Router, aIP, bIP string, cost int) {
+	if _, okA := allRouters[aIP]; !okA {
+		return
+	}
+	if _, okB := allRouters[bIP]; !okB {
+		return
+	}
+	if cost >= MAX_METRIC {
+		breakLink(allRouters, aIP, bIP)
+		return
+	}
+	formLink(allRouters, aIP, bIP, cost)
+}
+
+// publishRouteChange publishes the right structured event for a routing
+// table change to dest on router routerIP: RouteInstalled the first time
+// dest is learned, RouteWithdrawn once it becomes unreachable, and
+// MetricChanged for any other change to its metric.
+func publishRouteChange(routerIP, dest string, oldExists bool, oldMetric int, newEntry RouteEntry) {
+	switch {
+	case !oldExists:
+		eventbus.Publish(eventbus.RouteInstalledType, eventbus.RouteInstalled{
+			RouterID: routerIP, Destination: dest, NextHop: newEntry.NextHopIP, Metric: newEntry.Metric,
+		})
+	case newEntry.Metric >= MAX_METRIC && oldMetric < MAX_METRIC:
+		eventbus.Publish(eventbus.RouteWithdrawnType, eventbus.RouteWithdrawn{RouterID: routerIP, Destination: dest})
+	case newEntry.Metric != oldMetric:
+		eventbus.Publish(eventbus.MetricChangedType, eventbus.MetricChanged{
+			RouterID: routerIP, Destination: dest, OldMetric: oldMetric, NewMetric: newEntry.Metric,
+		})
+	}
+}
+
+func removeString(slice []string, s string) []string {
+	out := make([]string, 0, len(slice))
+	for _, v := range slice {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsString(slice []string, s string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pathEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// betterPath breaks a tie between two equal-cost routes to the same
+// destination: the shorter path wins, and if they're the same length the
+// lexicographically smaller one wins. The rule only needs to be
+// consistent, not meaningful, since its entire job is to stop two routers
+// from flapping between two equally good routes forever.
+func betterPath(a, b []string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func initializeRouterTable(r *Router, allRouters map[string]*Router) {
+	r.RoutingTable = make(map[string]RouteEntry)
+	r.RoutingTable[r.IP] = RouteEntry{DestinationIP: r.IP, NextHopIP: r.IP, Metric: 0}
+	for _, neighborIP := range r.NeighborIPs {
+		if _, exists := allRouters[neighborIP]; exists {
+			entry := RouteEntry{DestinationIP: neighborIP, NextHopIP: neighborIP, Metric: r.costTo(neighborIP), Path: []string{neighborIP}}
+			r.RoutingTable[neighborIP] = entry
+			publishRouteChange(r.IP, neighborIP, false, 0, entry)
+		}
+	}
+}
+
+func uniqueStrings(slice []string) []string {
+	keys := make(map[string]bool)
+	list := []string{}
+	for _, entry := range slice {
+		if _, value := keys[entry]; !value {
+			keys[entry] = true
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// Advertisement is what a router sends to a neighbor: its own IP plus a
+// snapshot of the routes it currently carries.
+type Advertisement struct {
+	FromIP string
+	Routes []RouteEntry
+}
+
+// SimulatorOptions controls the asynchronous simulator: how often routers
+// advertise on their own, how link delay/loss is emulated, and whether a
+// table change should trigger an out-of-band advertisement immediately.
+type SimulatorOptions struct {
+	JitterMax             time.Duration
+	DropProbability       float64
+	AdvertisementInterval time.Duration
+	TriggeredUpdates      bool
+	Rounds                int
+}
+
+func DefaultSimulatorOptions() SimulatorOptions {
+	return SimulatorOptions{
+		JitterMax:             50 * time.Millisecond,
+		DropProbability:       0,
+		AdvertisementInterval: 200 * time.Millisecond,
+		TriggeredUpdates:      true,
+		Rounds:                10,
+	}
+}
+
+// envelope is an advertisement in flight between two routers, addressed by
+// destination IP so the supervisor can delay/drop/reorder it independently
+// of the sender.
+type envelope struct {
+	toIP string
+	adv  Advertisement
+}
+
+func applyAdvertisement(r *Router, adv Advertisement) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !containsString(r.NeighborIPs, adv.FromIP) {
+		// adv.FromIP is no longer a neighbor, e.g. the link was broken by a
+		// TopologyEvent while this advertisement was already in flight.
+		// Ignore it instead of installing routes via it.
+		return false
+	}
+
+	changed := false
+	for _, advEntry := range adv.Routes {
+		if advEntry.DestinationIP == r.IP {
+			continue
+		}
+
+		costViaNeighbor := advEntry.Metric + r.costTo(adv.FromIP)
+		if costViaNeighbor > MAX_METRIC {
+			costViaNeighbor = MAX_METRIC
+		}
+
+		fullPath := append([]string{adv.FromIP}, advEntry.Path...)
+		if containsString(advEntry.Path, r.IP) || len(fullPath) > MaxPathLength {
+			// BGP-style loop detection: the advertised path already passes
+			// through us, or would grow past MaxPathLength. Treat the route
+			// as unreachable instead of installing it, which rules out
+			// count-to-infinity outright rather than just slowing it down.
+			costViaNeighbor = MAX_METRIC
+			fullPath = nil
+		}
+
+		currentEntry, exists := r.RoutingTable[advEntry.DestinationIP]
+		installedFromThisNeighbor := exists && adv.FromIP == currentEntry.NextHopIP
+
+		install := false
+		switch {
+		case !exists && costViaNeighbor < MAX_METRIC:
+			install = true
+		case exists && installedFromThisNeighbor && costViaNeighbor != currentEntry.Metric:
+			install = true
+		case exists && !installedFromThisNeighbor && costViaNeighbor < currentEntry.Metric:
+			install = true
+		case exists && !installedFromThisNeighbor && costViaNeighbor == currentEntry.Metric && betterPath(fullPath, currentEntry.Path):
+			install = true
+		case exists && installedFromThisNeighbor && costViaNeighbor == currentEntry.Metric && !pathEqual(fullPath, currentEntry.Path):
+			install = true
+		}
+
+		if install {
+			newEntry := RouteEntry{
+				DestinationIP: advEntry.DestinationIP,
+				NextHopIP:     adv.FromIP,
+				Metric:        costViaNeighbor,
+				Path:          fullPath,
+			}
+			r.RoutingTable[advEntry.DestinationIP] = newEntry
+			publishRouteChange(r.IP, advEntry.DestinationIP, exists, currentEntry.Metric, newEntry)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// routerLoop is the per-router event loop: it reacts to a periodic tick by
+// advertising its table to every neighbor, and to inbound advertisements by
+// updating its table and, if TriggeredUpdates is set, re-advertising right
+// away instead of waiting for the next tick.
+func routerLoop(r *Router, relay chan<- envelope, tick <-chan struct{}, quit <-chan struct{}, opts SimulatorOptions, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	advertise := func() {
+		r.mu.Lock()
+		routes := make([]RouteEntry, 0, len(r.RoutingTable))
+		for _, entry := range r.RoutingTable {
+			routes = append(routes, entry)
+		}
+		r.mu.Unlock()
+
+		for _, neighborIP := range r.NeighborIPs {
+			select {
+			case relay <- envelope{toIP: neighborIP, adv: Advertisement{FromIP: r.IP, Routes: routes}}:
+			case <-quit:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-tick:
+			advertise()
+		case adv := <-r.Inbox:
+			if applyAdvertisement(r, adv) && opts.TriggeredUpdates {
+				advertise()
+			}
+		}
+	}
+}
+
+// RunSimulationAsync runs the RIP simulation with one goroutine per router.
+// A supervisor goroutine relays advertisements between neighbors, adding
+// random jitter (and optionally dropping them) to emulate real, asynchronous
+// links instead of everyone seeing everyone else's table on a global clock.
+// onIteration, if non-nil, is called once per round with that round's number,
+// letting a caller snapshot the network's state as the simulation progresses
+// instead of only at the end.
+func RunSimulationAsync(allRouters map[string]*Router, opts SimulatorOptions, events []TopologyEvent, onIteration func(iteration int)) {
+	for _, r := range allRouters {
+		initializeRouterTable(r, allRouters)
+		r.Inbox = make(chan Advertisement, len(allRouters))
+	}
+
+	relay := make(chan envelope, len(allRouters)*4)
+	tick := make(chan struct{})
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for _, r := range allRouters {
+		wg.Add(1)
+		go routerLoop(r, relay, tick, quit, opts, &wg)
+	}
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		for env := range relay {
+			dest, ok := allRouters[env.toIP]
+			if !ok {
+				continue
+			}
+			if opts.DropProbability > 0 && rand.Float64() < opts.DropProbability {
+				continue
+			}
+			delay := time.Duration(0)
+			if opts.JitterMax > 0 {
+				delay = time.Duration(rand.Int63n(int64(opts.JitterMax)))
+			}
+			go func(dest *Router, adv Advertisement, delay time.Duration) {
+				timer := time.NewTimer(delay)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+				case <-quit:
+					return
+				}
+				select {
+				case dest.Inbox <- adv:
+				case <-quit:
+				}
+			}(dest, env.adv, delay)
+		}
+	}()
+
+	round := 0
+	for round = 1; round <= opts.Rounds; round++ {
+		applyTopologyEventsForStep(allRouters, events, round)
+
+		for range allRouters {
+			tick <- struct{}{}
+		}
+		time.Sleep(opts.AdvertisementInterval)
+
+		if onIteration != nil {
+			onIteration(round)
+		}
+	}
+
+	close(quit)
+	wg.Wait()
+	close(relay)
+	<-relayDone
+
+	eventbus.Publish(eventbus.ConvergenceReachedType, eventbus.ConvergenceReached{Iteration: round - 1})
+}
+
+func loadConfigFromFile(filePath string) (map[string]*Router, []TopologyEvent, Protocol, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to read config file '%s': %w", filePath, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to unmarshal JSON from '%s': %w", filePath, err)
+	}
+
+	protocol := config.Protocol
+	if protocol == "" {
+		protocol = ProtocolDV
+	}
+
+	allRouters := make(map[string]*Router)
+	for _, ipStr := range config.Routers {
+		allRouters[ipStr] = &Router{IP: ipStr, NeighborIPs: []string{}}
+	}
+
+	for _, link := range config.Links {
+		r1, ok1 := allRouters[link.From]
+		r2, ok2 := allRouters[link.To]
+		if ok1 && ok2 {
+			r1.NeighborIPs = append(r1.NeighborIPs, link.To)
+			r2.NeighborIPs = append(r2.NeighborIPs, link.From)
+		} else {
+			log.Printf("Warning: Invalid link %s-%s", link.From, link.To)
+		}
+	}
+	for _, r := range allRouters {
+		r.NeighborIPs = uniqueStrings(r.NeighborIPs)
+	}
+	return allRouters, config.Events, protocol, nil
+}
+
+func main() {
+	allRouters, events, protocol, err := loadConfigFromFile(CONFIG_FILE_NAME)
+	if err != nil {
+		log.Fatalf("Fatal error: Could not load or parse '%s': %v", CONFIG_FILE_NAME, err)
+		os.Exit(1)
+	}
+
+	console := eventbus.Subscribe("")
+	consoleDone := make(chan struct{})
+	go func() {
+		eventbus.RunConsolePrinter(console)
+		close(consoleDone)
+	}()
+	defer func() {
+		eventbus.Unsubscribe(console)
+		<-consoleDone
+	}()
+
+	// snapshotIteration writes one topology.<N>.dot/.json pair per simulation
+	// step, so the tui command can load and step through the whole run
+	// rather than only its final state.
+	snapshotIteration := func(iteration int) {
+		dotPath := fmt.Sprintf("topology.%d.dot", iteration)
+		jsonPath := fmt.Sprintf("topology.%d.json", iteration)
+		if err := writeSnapshotFiles(allRouters, iteration, dotPath, jsonPath); err != nil {
+			log.Printf("Warning: could not write topology snapshot for iteration %d: %v", iteration, err)
+		}
+	}
+
+	switch protocol {
+	case ProtocolLS:
+		RunLinkStateSimulation(allRouters, events, snapshotIteration)
+	default:
+		RunSimulationAsync(allRouters, DefaultSimulatorOptions(), events, snapshotIteration)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyAdvertisementRejectsPathContainingSelf(t *testing.T) {
+	r := &Router{IP: "a", NeighborIPs: []string{"b"}, RoutingTable: map[string]RouteEntry{
+		"a": {DestinationIP: "a", NextHopIP: "a", Metric: 0},
+	}}
+
+	changed := applyAdvertisement(r, Advertisement{FromIP: "b", Routes: []RouteEntry{
+		{DestinationIP: "c", NextHopIP: "b", Metric: 1, Path: []string{"a"}},
+	}})
+
+	if changed {
+		t.Fatalf("expected a route whose path already contains the receiver to be rejected")
+	}
+	if _, exists := r.RoutingTable["c"]; exists {
+		t.Fatalf("expected route to c to not be installed")
+	}
+}
+
+func TestApplyAdvertisementIgnoresStaleNeighbor(t *testing.T) {
+	r := &Router{IP: "a", NeighborIPs: []string{"c"}, RoutingTable: map[string]RouteEntry{
+		"a": {DestinationIP: "a", NextHopIP: "a", Metric: 0},
+	}}
+
+	// b is no longer in r.NeighborIPs (its link just went down), but an
+	// advertisement from it is still in flight.
+	changed := applyAdvertisement(r, Advertisement{FromIP: "b", Routes: []RouteEntry{
+		{DestinationIP: "b", NextHopIP: "b", Metric: 0},
+	}})
+
+	if changed {
+		t.Fatalf("expected an advertisement from a non-neighbor to be ignored")
+	}
+	if _, exists := r.RoutingTable["b"]; exists {
+		t.Fatalf("expected no route to b to be installed from a stale advertisement")
+	}
+}
+
+func TestBreakLinkOneSideMakesLinkUnreachableNotDefault(t *testing.T) {
+	r := &Router{IP: "a", NeighborIPs: []string{"b"}, LinkCost: map[string]int{"b": 1}, RoutingTable: map[string]RouteEntry{
+		"a": {DestinationIP: "a", NextHopIP: "a", Metric: 0},
+		"b": {DestinationIP: "b", NextHopIP: "b", Metric: 1},
+	}}
+
+	breakLinkOneSide(r, "b")
+
+	if cost := r.costTo("b"); cost != MAX_METRIC {
+		t.Fatalf("expected costTo a broken link to be MAX_METRIC, got %d", cost)
+	}
+}
+
+func TestAsyncSimulationReroutesAroundLinkDownMidRun(t *testing.T) {
+	a := &Router{IP: "a", NeighborIPs: []string{"b", "c"}}
+	b := &Router{IP: "b", NeighborIPs: []string{"a", "c"}}
+	c := &Router{IP: "c", NeighborIPs: []string{"a", "b"}}
+	allRouters := map[string]*Router{"a": a, "b": b, "c": c}
+
+	opts := DefaultSimulatorOptions()
+	opts.JitterMax = 0
+	opts.AdvertisementInterval = 20 * time.Millisecond
+	opts.Rounds = 10
+
+	events := []TopologyEvent{{Step: 3, Type: EventLinkDown, A: "a", B: "b"}}
+	RunSimulationAsync(allRouters, opts, events, nil)
+
+	entry := a.RoutingTable["b"]
+	if entry.NextHopIP == "b" {
+		t.Fatalf("expected a's route to b to reroute via c after the link went down, still got nextHop=%s metric=%d", entry.NextHopIP, entry.Metric)
+	}
+	if entry.Metric >= MAX_METRIC {
+		t.Fatalf("expected a to still reach b via c at a finite metric, got %d", entry.Metric)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"sort"
+
+	"github.com/RastorguevDanila/networks-course/export"
+)
+
+// buildSnapshot converts the live simulation state into the neutral
+// export.Snapshot the export package renders to DOT/JSON: every known
+// router, every link listed once regardless of which side's NeighborIPs it
+// was read from, and everyone's current routing table. Each router's state
+// is read under its own lock, since RunSimulationAsync's router goroutines
+// may still be running concurrently when a per-iteration snapshot is taken.
+func buildSnapshot(allRouters map[string]*Router, iteration int) export.Snapshot {
+	snap := export.Snapshot{
+		Iteration: iteration,
+		Routes:    make(map[string][]export.Route, len(allRouters)),
+	}
+
+	seenEdge := make(map[string]bool)
+	for ip, r := range allRouters {
+		snap.Nodes = append(snap.Nodes, export.Node{ID: ip})
+
+		r.mu.Lock()
+		neighborCosts := make(map[string]int, len(r.NeighborIPs))
+		for _, neighborIP := range r.NeighborIPs {
+			neighborCosts[neighborIP] = r.costTo(neighborIP)
+		}
+		routes := make([]export.Route, 0, len(r.RoutingTable))
+		for _, entry := range r.RoutingTable {
+			routes = append(routes, export.Route{
+				Destination: entry.DestinationIP,
+				NextHop:     entry.NextHopIP,
+				Metric:      entry.Metric,
+				Path:        entry.Path,
+			})
+		}
+		r.mu.Unlock()
+
+		for neighborIP, cost := range neighborCosts {
+			a, b := ip, neighborIP
+			if a > b {
+				a, b = b, a
+			}
+			key := a + "|" + b
+			if seenEdge[key] {
+				continue
+			}
+			seenEdge[key] = true
+			snap.Edges = append(snap.Edges, export.Edge{From: a, To: b, Cost: cost})
+		}
+
+		snap.Routes[ip] = routes
+	}
+	return snap
+}
+
+// writeSnapshotFiles writes the current simulation state to dotPath and
+// jsonPath, rooting the DOT graph's shortest-path-tree coloring at the
+// lexicographically smallest router IP so the choice is deterministic.
+func writeSnapshotFiles(allRouters map[string]*Router, iteration int, dotPath, jsonPath string) error {
+	snap := buildSnapshot(allRouters, iteration)
+
+	rootID := ""
+	if len(snap.Nodes) > 0 {
+		ids := make([]string, len(snap.Nodes))
+		for i, n := range snap.Nodes {
+			ids[i] = n.ID
+		}
+		sort.Strings(ids)
+		rootID = ids[0]
+	}
+
+	dotFile, err := os.Create(dotPath)
+	if err != nil {
+		return err
+	}
+	defer dotFile.Close()
+	if err := export.WriteDOT(dotFile, snap, rootID); err != nil {
+		return err
+	}
+
+	jsonFile, err := os.Create(jsonPath)
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+	return export.WriteJSON(jsonFile, snap)
+}
@@ -0,0 +1,229 @@
+// Command tui is an interactive viewer for the per-iteration snapshots
+// written by lab11/sol and lab12/rip (see writeSnapshotFiles in both): one
+// pane draws the network graph, the other shows the routing table of
+// whichever router is currently selected. Arrow keys step between loaded
+// iterations and between routers; "f" toggles a link as failed for a
+// what-if view of the graph pane, recoloring the affected router's
+// shortest-path tree without touching the recorded snapshot on disk.
+//
+// It lives in its own module because tview/tcell are dependencies the rest
+// of this repo doesn't otherwise need.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/RastorguevDanila/networks-course/export"
+)
+
+// loadSnapshots reads every "topology.*.json" file in dir (one per
+// iteration, as written by writeSnapshotFiles) and returns them sorted by
+// Iteration.
+func loadSnapshots(dir string) ([]export.Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "topology.*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]export.Snapshot, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		var snap export.Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Iteration < snapshots[j].Iteration })
+	return snapshots, nil
+}
+
+// session holds everything the UI callbacks need: the loaded iterations,
+// which one and which router are currently selected, and the set of links
+// the user has toggled failed for the what-if view.
+type session struct {
+	snapshots    []export.Snapshot
+	iterationIdx int
+	routerIDs    []string
+	routerIdx    int
+	failedEdges  map[string]bool
+}
+
+func newSession(snapshots []export.Snapshot) *session {
+	s := &session{snapshots: snapshots, failedEdges: make(map[string]bool)}
+	s.refreshRouterIDs()
+	return s
+}
+
+func (s *session) current() export.Snapshot {
+	return s.snapshots[s.iterationIdx]
+}
+
+func (s *session) refreshRouterIDs() {
+	s.routerIDs = s.routerIDs[:0]
+	for _, n := range s.current().Nodes {
+		s.routerIDs = append(s.routerIDs, n.ID)
+	}
+	sort.Strings(s.routerIDs)
+	if s.routerIdx >= len(s.routerIDs) {
+		s.routerIdx = 0
+	}
+}
+
+func (s *session) selectedRouter() string {
+	if len(s.routerIDs) == 0 {
+		return ""
+	}
+	return s.routerIDs[s.routerIdx]
+}
+
+func (s *session) stepIteration(delta int) {
+	next := s.iterationIdx + delta
+	if next < 0 || next >= len(s.snapshots) {
+		return
+	}
+	s.iterationIdx = next
+	s.refreshRouterIDs()
+}
+
+func (s *session) stepRouter(delta int) {
+	if len(s.routerIDs) == 0 {
+		return
+	}
+	s.routerIdx = (s.routerIdx + delta + len(s.routerIDs)) % len(s.routerIDs)
+}
+
+func (s *session) toggleFailure(a, b string) {
+	key := edgeKey(a, b)
+	s.failedEdges[key] = !s.failedEdges[key]
+}
+
+func edgeKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// graphText renders the current iteration's topology as indented text: one
+// line per router, with its links and their cost, struck through with
+// "(down)" for links the user toggled failed.
+func graphText(s *session) string {
+	snap := s.current()
+	adjacency := make(map[string][]export.Edge)
+	for _, e := range snap.Edges {
+		adjacency[e.From] = append(adjacency[e.From], e)
+		adjacency[e.To] = append(adjacency[e.To], export.Edge{From: e.To, To: e.From, Cost: e.Cost})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Iteration %d\n\n", snap.Iteration)
+	for _, id := range s.routerIDs {
+		marker := "  "
+		if id == s.selectedRouter() {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "%s%s\n", marker, id)
+		for _, e := range adjacency[id] {
+			status := ""
+			if s.failedEdges[edgeKey(e.From, e.To)] {
+				status = " (down)"
+			}
+			fmt.Fprintf(&b, "      -- %s (cost %d)%s\n", e.To, e.Cost, status)
+		}
+	}
+	return b.String()
+}
+
+// routeTableText renders the selected router's routing table, skipping any
+// destination reached only through a link the user toggled failed.
+func routeTableText(s *session) string {
+	routerID := s.selectedRouter()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Routing table: %s\n\n", routerID)
+	fmt.Fprintln(&b, "Destination  NextHop  Metric  Path")
+	for _, route := range s.current().Routes[routerID] {
+		if s.failedEdges[edgeKey(routerID, route.NextHop)] {
+			fmt.Fprintf(&b, "%-12s %-8s (unreachable: link down)\n", route.Destination, route.NextHop)
+			continue
+		}
+		fmt.Fprintf(&b, "%-12s %-8s %-7d %s\n", route.Destination, route.NextHop, route.Metric, strings.Join(route.Path, " -> "))
+	}
+	return b.String()
+}
+
+func main() {
+	dir := "."
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+
+	snapshots, err := loadSnapshots(dir)
+	if err != nil {
+		log.Fatalf("Fatal error: could not load snapshots from %s: %v", dir, err)
+	}
+	if len(snapshots) == 0 {
+		log.Fatalf("Fatal error: no topology.*.json snapshots found in %s", dir)
+	}
+
+	s := newSession(snapshots)
+
+	graphView := tview.NewTextView().SetDynamicColors(true)
+	tableView := tview.NewTextView().SetDynamicColors(true)
+
+	render := func() {
+		graphView.SetText(graphText(s))
+		tableView.SetText(routeTableText(s))
+	}
+	render()
+
+	layout := tview.NewFlex().
+		AddItem(graphView, 0, 1, true).
+		AddItem(tableView, 0, 1, false)
+
+	app := tview.NewApplication()
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			s.stepIteration(-1)
+		case tcell.KeyRight:
+			s.stepIteration(1)
+		case tcell.KeyUp:
+			s.stepRouter(-1)
+		case tcell.KeyDown:
+			s.stepRouter(1)
+		case tcell.KeyEsc:
+			app.Stop()
+			return nil
+		case tcell.KeyRune:
+			if event.Rune() == 'f' {
+				router := s.selectedRouter()
+				for _, route := range s.current().Routes[router] {
+					s.toggleFailure(router, route.NextHop)
+					break
+				}
+			}
+		default:
+			return event
+		}
+		render()
+		return nil
+	})
+
+	if err := app.SetRoot(layout, true).Run(); err != nil {
+		log.Fatalf("Fatal error: TUI exited: %v", err)
+	}
+}